@@ -0,0 +1,57 @@
+package multipart
+
+import (
+	"fmt"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// boundaryChars matches RFC 2046's bchars: DIGIT / ALPHA / "'" / "(" / ")" /
+// "+" / "_" / "," / "-" / "." / "/" / ":" / "=" / "?" / " ".
+var boundaryChars = regexp.MustCompile(`^[A-Za-z0-9'()+_,\-./:=? ]+$`)
+
+// SetBoundary fixes the multipart boundary e uses instead of the random one
+// multipart.Writer generates by default, so Encode's output is
+// byte-for-byte reproducible across runs. Combined with
+// EncodeOptions.Deterministic, this makes golden-file tests, request
+// signing (e.g. S3 POST policy uploads), and content-addressed cache keys
+// possible.
+//
+// boundary is validated per RFC 2046: it must be 1-70 characters from the
+// bchars set and must not end in a space.
+func (e *Encoder) SetBoundary(boundary string) error {
+	if len(boundary) == 0 || len(boundary) > 70 {
+		return fmt.Errorf("multipart: invalid boundary length %d, want 1-70", len(boundary))
+	}
+	if !boundaryChars.MatchString(boundary) {
+		return fmt.Errorf("multipart: boundary %q contains characters outside RFC 2046's bchars", boundary)
+	}
+	if strings.HasSuffix(boundary, " ") {
+		return fmt.Errorf("multipart: boundary %q must not end with a space", boundary)
+	}
+
+	e.boundary = boundary
+	return nil
+}
+
+// orderedFieldIndices returns the indices of t's fields in the order they
+// should be encoded: declaration order normally, or sorted by resolved form
+// field name when deterministic is true.
+func orderedFieldIndices(t reflect.Type, deterministic bool) []int {
+	indices := make([]int, t.NumField())
+	for i := range indices {
+		indices[i] = i
+	}
+
+	if deterministic {
+		sort.Slice(indices, func(i, j int) bool {
+			ni, _ := formFieldName(t.Field(indices[i]))
+			nj, _ := formFieldName(t.Field(indices[j]))
+			return ni < nj
+		})
+	}
+
+	return indices
+}
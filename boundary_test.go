@@ -0,0 +1,68 @@
+package multipart
+
+import (
+	"strings"
+	"testing"
+)
+
+type deterministicRequest struct {
+	Zebra string `form:"zebra"`
+	Alpha string `form:"alpha"`
+	Mike  string `form:"mike"`
+}
+
+func TestSetBoundaryValidation(t *testing.T) {
+	e := NewEncoder()
+
+	if err := e.SetBoundary("valid-Boundary123"); err != nil {
+		t.Fatalf("SetBoundary rejected a valid boundary: %v", err)
+	}
+
+	if err := e.SetBoundary(""); err == nil {
+		t.Error("SetBoundary accepted an empty boundary")
+	}
+
+	if err := e.SetBoundary(string(make([]byte, 71))); err == nil {
+		t.Error("SetBoundary accepted a boundary longer than 70 characters")
+	}
+
+	if err := e.SetBoundary("not;valid"); err == nil {
+		t.Error("SetBoundary accepted a boundary with a disallowed character")
+	}
+
+	if err := e.SetBoundary("trailing-space "); err == nil {
+		t.Error("SetBoundary accepted a boundary ending in a space")
+	}
+}
+
+func TestEncodeDeterministic(t *testing.T) {
+	e := NewEncoder()
+	if err := e.SetBoundary("fixedboundary"); err != nil {
+		t.Fatalf("SetBoundary returned an error: %v", err)
+	}
+	e.Options = EncodeOptions{Deterministic: true}
+
+	req := deterministicRequest{Zebra: "z", Alpha: "a", Mike: "m"}
+
+	buf1, contentType, err := e.Encode(req)
+	if err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+	buf2, _, err := e.Encode(req)
+	if err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+
+	if buf1.String() != buf2.String() {
+		t.Error("Encode output was not byte-for-byte reproducible across runs")
+	}
+
+	if contentType == "" || !strings.Contains(contentType, "fixedboundary") {
+		t.Errorf("contentType = %q, want it to contain the fixed boundary", contentType)
+	}
+
+	out := buf1.String()
+	if idx := strings.Index(out, "alpha"); idx == -1 || strings.Index(out, "mike") < idx || strings.Index(out, "zebra") < idx {
+		t.Error("Deterministic fields were not emitted in sorted order")
+	}
+}
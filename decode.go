@@ -0,0 +1,291 @@
+package multipart
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+var bytesType = reflect.TypeOf([]byte(nil))
+
+// DefaultMaxMemory is the default value of DecodeOptions.MaxMemory, mirroring
+// http.Request's own default for ParseMultipartForm.
+const DefaultMaxMemory = 32 << 20 // 32 MB
+
+// DecodeOptions configures DecodeWithOptions.
+type DecodeOptions struct {
+	// MaxMemory caps how many bytes of file parts are held in memory before
+	// the underlying multipart.Reader spills additional parts to temporary
+	// files on disk. Defaults to DefaultMaxMemory when zero.
+	MaxMemory int64
+
+	// MaxPartSize, if non-zero, rejects any part decoded into a field of dst
+	// that is larger than this many bytes; a gzip-encoded part read into a
+	// []byte or io.Reader field is also checked after decompression. Parts
+	// are checked after ReadForm has stored them, so this bounds what Decode
+	// reads into memory, not what ReadForm buffers or spills to disk.
+	MaxPartSize int64
+}
+
+// Decode reads a multipart/form-data body from r and populates dst, which
+// must be a pointer to a struct. It is the inverse of Encode: dst's fields
+// are matched against part names using the same `form:"..."` tag convention
+// Encode uses, honoring `form:"-"` to skip a field.
+//
+// Supported destination field kinds mirror Encode: strings, ints, uints,
+// floats, and bools are parsed with strconv from the part body; []byte,
+// io.Reader (declared as the bare interface type, not a concrete
+// implementation like *os.File, since Decode has no way to construct one),
+// and *multipart.FileHeader fields are populated from file parts ([]byte and
+// io.Reader are read fully into memory, while a *multipart.FileHeader field
+// keeps the part's disk/memory storage intact so the caller can decide when
+// to open it); slices collect repeated parts sharing the same name; and
+// struct fields are populated via
+// json.Unmarshal of the part body. A []byte or io.Reader part whose
+// Content-Encoding header is "gzip" (as produced by a `contentEncoding:"gzip"`
+// field tag) is transparently gunzipped, so Decode yields the same bytes that
+// were originally passed to Encode; a *multipart.FileHeader destination gets
+// the part as-is and the caller is responsible for gunzipping it.
+//
+// Decode does not stop at the first failing field - it collects every
+// field-level error and returns them joined with errors.Join, so callers see
+// every problem with the request at once.
+func Decode(r io.Reader, boundary string, dst any) error {
+	return DecodeWithOptions(r, boundary, dst, DecodeOptions{})
+}
+
+// DecodeRequest is a convenience wrapper around Decode that extracts the
+// boundary from req's Content-Type header before decoding req.Body into dst.
+func DecodeRequest(req *http.Request, dst any) error {
+	mediaType, params, err := mime.ParseMediaType(req.Header.Get("Content-Type"))
+	if err != nil {
+		return fmt.Errorf("failed to parse Content-Type: %w", err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		return fmt.Errorf("request does not contain a multipart body")
+	}
+	boundary, ok := params["boundary"]
+	if !ok {
+		return fmt.Errorf("Content-Type is missing a multipart boundary")
+	}
+
+	return Decode(req.Body, boundary, dst)
+}
+
+// DecodeWithOptions is Decode with explicit control over memory and
+// per-part size limits via opts.
+func DecodeWithOptions(r io.Reader, boundary string, dst any, opts DecodeOptions) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Ptr || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("dst must be a pointer to a struct")
+	}
+	v = v.Elem()
+	t := v.Type()
+
+	maxMemory := opts.MaxMemory
+	if maxMemory <= 0 {
+		maxMemory = DefaultMaxMemory
+	}
+
+	mr := multipart.NewReader(r, boundary)
+	form, err := mr.ReadForm(maxMemory)
+	if err != nil {
+		return fmt.Errorf("failed to read multipart form: %w", err)
+	}
+
+	keepFiles := false
+	for i := 0; i < t.NumField(); i++ {
+		if isFileHeaderField(t.Field(i).Type) {
+			keepFiles = true
+			break
+		}
+	}
+	if !keepFiles {
+		defer form.RemoveAll()
+	}
+
+	var errs []error
+	for i := 0; i < t.NumField(); i++ {
+		field := v.Field(i)
+		fieldType := t.Field(i)
+
+		fieldName, skip := formFieldName(fieldType)
+		if skip {
+			continue
+		}
+
+		if err := decodeField(field, fieldType, fieldName, form, opts.MaxPartSize); err != nil {
+			errs = append(errs, fmt.Errorf("field %q: %w", fieldName, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func isFileHeaderField(fieldType reflect.Type) bool {
+	if fieldType == fileHeaderType {
+		return true
+	}
+	return fieldType.Kind() == reflect.Slice && fieldType.Elem() == fileHeaderType
+}
+
+func decodeField(field reflect.Value, fieldType reflect.StructField, fieldName string, form *multipart.Form, maxPartSize int64) error {
+	if files := form.File[fieldName]; len(files) > 0 {
+		return decodeFileField(field, files, maxPartSize)
+	}
+
+	values := form.Value[fieldName]
+	if len(values) == 0 {
+		return nil
+	}
+	for _, raw := range values {
+		if maxPartSize > 0 && int64(len(raw)) > maxPartSize {
+			return fmt.Errorf("part exceeds max part size of %d bytes", maxPartSize)
+		}
+	}
+
+	if field.Kind() == reflect.Slice && field.Type().Elem().Kind() != reflect.Uint8 {
+		elems := reflect.MakeSlice(field.Type(), len(values), len(values))
+		for i, raw := range values {
+			if err := setScalarField(elems.Index(i), raw); err != nil {
+				return err
+			}
+		}
+		field.Set(elems)
+		return nil
+	}
+
+	if field.Kind() == reflect.Struct {
+		return json.Unmarshal([]byte(values[0]), field.Addr().Interface())
+	}
+
+	return setScalarField(field, values[0])
+}
+
+func decodeFileField(field reflect.Value, files []*multipart.FileHeader, maxPartSize int64) error {
+	for _, fh := range files {
+		if maxPartSize > 0 && fh.Size > maxPartSize {
+			return fmt.Errorf("part %q exceeds max part size of %d bytes", fh.Filename, maxPartSize)
+		}
+	}
+
+	switch {
+	case field.Type() == fileHeaderType:
+		field.Set(reflect.ValueOf(files[0]))
+		return nil
+
+	case field.Kind() == reflect.Slice && field.Type().Elem() == fileHeaderType:
+		elems := reflect.MakeSlice(field.Type(), len(files), len(files))
+		for i, fh := range files {
+			elems.Index(i).Set(reflect.ValueOf(fh))
+		}
+		field.Set(elems)
+		return nil
+
+	case field.Type() == bytesType:
+		data, err := readFilePart(files[0], maxPartSize)
+		if err != nil {
+			return err
+		}
+		field.SetBytes(data)
+		return nil
+
+	case field.Type() == readerType:
+		data, err := readFilePart(files[0], maxPartSize)
+		if err != nil {
+			return err
+		}
+		field.Set(reflect.ValueOf(bytes.NewReader(data)))
+		return nil
+	}
+
+	return fmt.Errorf("unsupported destination kind %s for file part", field.Type())
+}
+
+// readFilePart reads fh's content into memory, transparently gunzipping it
+// if it was written with a `contentEncoding:"gzip"` tag (signaled by a
+// Content-Encoding: gzip part header), so it mirrors whatever bytes were
+// passed to Encode regardless of how they were compressed on the wire.
+//
+// maxPartSize, if non-zero, also caps the decompressed size: the on-wire
+// fh.Size check in decodeFileField only bounds the compressed bytes, so a
+// small gzip bomb would otherwise expand unbounded in memory.
+func readFilePart(fh *multipart.FileHeader, maxPartSize int64) ([]byte, error) {
+	f, err := fh.Open()
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var r io.Reader = f
+	if fh.Header.Get("Content-Encoding") == "gzip" {
+		gr, err := gzip.NewReader(f)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress gzip part %q: %w", fh.Filename, err)
+		}
+		defer gr.Close()
+		r = gr
+	}
+
+	if maxPartSize <= 0 {
+		return io.ReadAll(r)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, maxPartSize+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > maxPartSize {
+		return nil, fmt.Errorf("part %q exceeds max part size of %d bytes after decompression", fh.Filename, maxPartSize)
+	}
+	return data, nil
+}
+
+func setScalarField(field reflect.Value, raw string) error {
+	switch field.Kind() {
+	case reflect.String:
+		field.SetString(raw)
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetInt(n)
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(raw, 10, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetUint(n)
+
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(raw, field.Type().Bits())
+		if err != nil {
+			return err
+		}
+		field.SetFloat(n)
+
+	case reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return err
+		}
+		field.SetBool(b)
+
+	default:
+		return fmt.Errorf("unsupported destination kind %s", field.Kind())
+	}
+
+	return nil
+}
@@ -0,0 +1,204 @@
+package multipart
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"strings"
+	"testing"
+)
+
+type decodeTarget struct {
+	Name     string `form:"name"`
+	Age      int    `form:"user_age"`
+	IsActive bool
+	Ignored  string `form:"-"`
+	Tags     []string
+	Avatar   []byte `form:"avatar"`
+	Doc      io.Reader
+	Header   *multipart.FileHeader `form:"upload"`
+}
+
+func buildMultipartBody(t *testing.T) (*bytes.Buffer, string) {
+	t.Helper()
+
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+
+	fields := map[string]string{
+		"name":     "Jane Doe",
+		"user_age": "27",
+		"isactive": "true",
+	}
+	for k, v := range fields {
+		if err := w.WriteField(k, v); err != nil {
+			t.Fatalf("failed to write field %q: %v", k, err)
+		}
+	}
+	for _, tag := range []string{"golang", "multipart"} {
+		if err := w.WriteField("tags", tag); err != nil {
+			t.Fatalf("failed to write tags field: %v", err)
+		}
+	}
+
+	avatar, err := w.CreateFormFile("avatar", "avatar.png")
+	if err != nil {
+		t.Fatalf("failed to create avatar part: %v", err)
+	}
+	avatar.Write([]byte("binary image data"))
+
+	doc, err := w.CreateFormFile("doc", "doc.txt")
+	if err != nil {
+		t.Fatalf("failed to create doc part: %v", err)
+	}
+	doc.Write([]byte("doc contents"))
+
+	upload, err := w.CreateFormFile("upload", "upload.bin")
+	if err != nil {
+		t.Fatalf("failed to create upload part: %v", err)
+	}
+	upload.Write([]byte("upload contents"))
+
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+
+	return &b, w.Boundary()
+}
+
+func TestDecode(t *testing.T) {
+	body, boundary := buildMultipartBody(t)
+
+	var dst decodeTarget
+	if err := Decode(body, boundary, &dst); err != nil {
+		t.Fatalf("Decode returned an error: %v", err)
+	}
+
+	if dst.Name != "Jane Doe" {
+		t.Errorf("Name = %q, want %q", dst.Name, "Jane Doe")
+	}
+	if dst.Age != 27 {
+		t.Errorf("Age = %d, want %d", dst.Age, 27)
+	}
+	if !dst.IsActive {
+		t.Error("IsActive = false, want true")
+	}
+	if dst.Ignored != "" {
+		t.Errorf("Ignored = %q, want empty", dst.Ignored)
+	}
+	if len(dst.Tags) != 2 {
+		t.Fatalf("len(Tags) = %d, want 2", len(dst.Tags))
+	}
+	if string(dst.Avatar) != "binary image data" {
+		t.Errorf("Avatar = %q, want %q", dst.Avatar, "binary image data")
+	}
+
+	docData, err := io.ReadAll(dst.Doc)
+	if err != nil {
+		t.Fatalf("failed to read Doc: %v", err)
+	}
+	if string(docData) != "doc contents" {
+		t.Errorf("Doc = %q, want %q", docData, "doc contents")
+	}
+
+	if dst.Header == nil || dst.Header.Filename != "upload.bin" {
+		t.Fatalf("Header = %v, want a file header named upload.bin", dst.Header)
+	}
+}
+
+func TestDecodeGunzipsGzipTaggedField(t *testing.T) {
+	large := strings.Repeat("a", gzipMinSize+1)
+
+	buf, contentType, err := Encode(contentTypeRequest{
+		Payload: []byte(large),
+		Small:   []byte("tiny"),
+	})
+	if err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+
+	var dst struct {
+		Payload []byte `form:"payload"`
+		Small   []byte `form:"small"`
+	}
+	if err := Decode(buf, params["boundary"], &dst); err != nil {
+		t.Fatalf("Decode returned an error: %v", err)
+	}
+
+	if string(dst.Payload) != large {
+		t.Error("Decode did not gunzip a gzip-tagged field back to its original content")
+	}
+	if string(dst.Small) != "tiny" {
+		t.Errorf("Small = %q, want %q", dst.Small, "tiny")
+	}
+}
+
+func TestDecodeCapsDecompressedGzipSize(t *testing.T) {
+	large := strings.Repeat("a", gzipMinSize+1)
+
+	buf, contentType, err := Encode(contentTypeRequest{Payload: []byte(large)})
+	if err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+
+	// The compressed part is well under this limit (it is a run of the same
+	// byte), but the decompressed payload is not - this must be caught by
+	// readFilePart's post-decompression check, not the on-wire fh.Size check.
+	const maxPartSize = 100
+
+	var dst struct {
+		Payload []byte `form:"payload"`
+	}
+	err = DecodeWithOptions(buf, params["boundary"], &dst, DecodeOptions{MaxPartSize: maxPartSize})
+	if err == nil {
+		t.Fatal("Decode returned no error for a decompressed payload exceeding MaxPartSize")
+	}
+}
+
+func TestDecodeUnsupportedReaderFieldType(t *testing.T) {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	f, err := w.CreateFormFile("file", "upload.bin")
+	if err != nil {
+		t.Fatalf("failed to create file part: %v", err)
+	}
+	f.Write([]byte("contents"))
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+
+	var dst struct {
+		File *os.File `form:"file"`
+	}
+	err = Decode(&b, w.Boundary(), &dst)
+	if err == nil {
+		t.Fatal("Decode returned no error for an unsupported *os.File destination")
+	}
+}
+
+func TestDecodeCollectsFieldErrors(t *testing.T) {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+	if err := w.WriteField("user_age", "not-a-number"); err != nil {
+		t.Fatalf("failed to write field: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("failed to close writer: %v", err)
+	}
+
+	var dst decodeTarget
+	err := Decode(&b, w.Boundary(), &dst)
+	if err == nil {
+		t.Fatal("Decode returned no error for an invalid field")
+	}
+}
@@ -0,0 +1,226 @@
+package multipart
+
+import (
+	"bytes"
+	"fmt"
+	"mime/multipart"
+	"net/textproto"
+	"reflect"
+	"time"
+)
+
+// EncodeFunc writes a single struct field's value to w as one or more parts
+// named fieldName.
+type EncodeFunc func(w *multipart.Writer, fieldName string, field reflect.Value, fieldType reflect.StructField) error
+
+// Encoder encodes structs into multipart/form-data bodies. It behaves like
+// the package-level Encode, but lets callers register custom encoders for
+// specific types or reflect.Kinds via RegisterType and RegisterKind, so
+// types this package doesn't natively understand can be supported without
+// forking it.
+type Encoder struct {
+	typeEncoders map[reflect.Type]EncodeFunc
+	kindEncoders map[reflect.Kind]EncodeFunc
+	boundary     string
+
+	// Options controls nested-value rendering and field ordering; see
+	// EncodeOptions.
+	Options EncodeOptions
+}
+
+// NewEncoder returns an Encoder with no custom encoders registered. Unlike
+// DefaultEncoder it has no time.Time encoder, so a time.Time field takes
+// the built-in struct handling; every other field is encoded as the
+// package-level Encode would.
+func NewEncoder() *Encoder {
+	return &Encoder{
+		typeEncoders: make(map[reflect.Type]EncodeFunc),
+		kindEncoders: make(map[reflect.Kind]EncodeFunc),
+	}
+}
+
+// WithOptions returns a shallow copy of e with Options replaced by opts,
+// leaving e itself and its registered encoders untouched.
+func (e *Encoder) WithOptions(opts EncodeOptions) *Encoder {
+	clone := *e
+	clone.Options = opts
+	return &clone
+}
+
+// DefaultEncoder is the Encoder used by the package-level Encode and
+// EncodeStream. Calling RegisterType or RegisterKind on it customizes
+// encoding package-wide.
+var DefaultEncoder = newDefaultEncoder()
+
+func newDefaultEncoder() *Encoder {
+	e := NewEncoder()
+	e.RegisterType(reflect.TypeOf(time.Time{}), encodeTimeField)
+	return e
+}
+
+// RegisterType registers fn as the encoder for every field of exactly type
+// t. Type encoders take precedence over kind encoders and the built-in
+// defaults, but not over a field that implements Marshaler.
+func (e *Encoder) RegisterType(t reflect.Type, fn EncodeFunc) {
+	e.typeEncoders[t] = fn
+}
+
+// RegisterKind registers fn as the encoder for every field whose
+// reflect.Kind is k, unless a more specific type encoder is registered for
+// that field's exact type.
+func (e *Encoder) RegisterKind(k reflect.Kind, fn EncodeFunc) {
+	e.kindEncoders[k] = fn
+}
+
+// Encode converts req into multipart/form-data format using e's registered
+// encoders, falling back to the package's built-in defaults for anything
+// unregistered. See the package-level Encode for the tag conventions and
+// per-kind behavior those defaults follow.
+func (e *Encoder) Encode(req any) (*bytes.Buffer, string, error) {
+	var b bytes.Buffer
+	w := multipart.NewWriter(&b)
+
+	if e.boundary != "" {
+		if err := w.SetBoundary(e.boundary); err != nil {
+			return nil, "", err
+		}
+	}
+
+	if err := e.writeFields(w, req); err != nil {
+		return nil, "", err
+	}
+
+	if err := w.Close(); err != nil {
+		return nil, "", fmt.Errorf("failed to close multipart writer %w", err)
+	}
+
+	return &b, w.FormDataContentType(), nil
+}
+
+// writeFields walks the fields of req and encodes each one to w, applying
+// the same field-name and skip-field tag rules the package-level Encode
+// uses.
+func (e *Encoder) writeFields(w *multipart.Writer, req any) error {
+	v := reflect.ValueOf(req)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+
+	if v.Kind() != reflect.Struct {
+		return fmt.Errorf("req must be a struct")
+	}
+
+	t := v.Type()
+	for _, i := range orderedFieldIndices(t, e.Options.Deterministic) {
+		field := v.Field(i)
+		fieldType := t.Field(i)
+
+		fieldName, skip := formFieldName(fieldType)
+		if skip {
+			continue
+		}
+
+		if err := e.encodeField(w, fieldName, field, fieldType); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// encodeField dispatches a single field, in order, to: a Marshaler
+// implementation, a registered type encoder, a registered kind encoder, and
+// finally the package's built-in kind-based defaults.
+func (e *Encoder) encodeField(w *multipart.Writer, fieldName string, field reflect.Value, fieldType reflect.StructField) error {
+	if m, ok := marshalerFor(field); ok {
+		return writeMarshaled(w, fieldName, m)
+	}
+
+	if fn, ok := e.typeEncoders[field.Type()]; ok {
+		return fn(w, fieldName, field, fieldType)
+	}
+
+	if fn, ok := e.kindEncoders[field.Kind()]; ok {
+		return fn(w, fieldName, field, fieldType)
+	}
+
+	switch {
+	case field.Kind() == reflect.Ptr && field.Type() != osFileType && field.Type() != fileHeaderType && !field.Type().Implements(readerType):
+		if field.IsNil() {
+			return nil
+		}
+		return e.encodeField(w, fieldName, field.Elem(), fieldType)
+
+	case field.Kind() == reflect.Map:
+		return e.encodeMap(w, fieldName, field)
+
+	case field.Kind() == reflect.Struct && e.Options.NestedStyle != JSONBlob:
+		return e.encodeStructFields(w, fieldName, field)
+
+	case field.Kind() == reflect.Slice && field.Type().Elem().Kind() == reflect.Struct:
+		if field.IsNil() {
+			return nil
+		}
+		if e.Options.NestedStyle == JSONBlob {
+			return writeJSONField(w, fieldName, field)
+		}
+		return e.encodeSliceOfStruct(w, fieldName, field)
+	}
+
+	return writeField(w, fieldName, field, fieldType)
+}
+
+// marshalerFor reports whether field, or its address when field is
+// addressable, implements Marshaler.
+func marshalerFor(field reflect.Value) (Marshaler, bool) {
+	if field.CanInterface() {
+		if m, ok := field.Interface().(Marshaler); ok {
+			return m, true
+		}
+	}
+	if field.CanAddr() {
+		if m, ok := field.Addr().Interface().(Marshaler); ok {
+			return m, true
+		}
+	}
+	return nil, false
+}
+
+// writeMarshaled writes the part produced by a Marshaler, defaulting its
+// Content-Disposition to the field's form name when the Marshaler didn't
+// set one.
+func writeMarshaled(w *multipart.Writer, fieldName string, m Marshaler) error {
+	data, header, err := m.MarshalMultipart()
+	if err != nil {
+		return fmt.Errorf("MarshalMultipart: %w", err)
+	}
+
+	if header == nil {
+		header = make(textproto.MIMEHeader)
+	}
+	if header.Get("Content-Disposition") == "" {
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q`, fieldName))
+	}
+
+	pw, err := w.CreatePart(header)
+	if err != nil {
+		return err
+	}
+	_, err = pw.Write(data)
+	return err
+}
+
+// encodeTimeField is the default type encoder for time.Time fields,
+// registered on DefaultEncoder. It formats the field as RFC 3339 instead of
+// letting it fall through to the reflect.Struct default, which would
+// JSON-encode it (as a quoted string) with no indication of its format.
+func encodeTimeField(w *multipart.Writer, fieldName string, field reflect.Value, _ reflect.StructField) error {
+	t := field.Interface().(time.Time)
+
+	fw, err := w.CreateFormField(fieldName)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write([]byte(t.Format(time.RFC3339)))
+	return err
+}
@@ -0,0 +1,127 @@
+package multipart
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/textproto"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+type upperCase string
+
+func (u upperCase) MarshalMultipart() ([]byte, textproto.MIMEHeader, error) {
+	return []byte(strings.ToUpper(string(u))), nil, nil
+}
+
+type encoderRequest struct {
+	CreatedAt time.Time `form:"created_at"`
+	Shout     upperCase `form:"shout"`
+	Custom    int       `form:"custom"`
+}
+
+func readParts(t *testing.T, buf io.Reader, boundary string) map[string]string {
+	t.Helper()
+
+	parts := make(map[string]string)
+	r := multipart.NewReader(buf, boundary)
+	for {
+		p, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+		data, err := io.ReadAll(p)
+		if err != nil {
+			t.Fatalf("failed to read part body: %v", err)
+		}
+		parts[p.FormName()] = string(data)
+	}
+	return parts
+}
+
+func TestEncodeTimeField(t *testing.T) {
+	when := time.Date(2024, 3, 15, 10, 30, 0, 0, time.UTC)
+	buf, contentType, err := Encode(encoderRequest{CreatedAt: when})
+	if err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+
+	parts := readParts(t, buf, params["boundary"])
+	if parts["created_at"] != when.Format(time.RFC3339) {
+		t.Errorf("created_at = %q, want %q", parts["created_at"], when.Format(time.RFC3339))
+	}
+}
+
+func TestEncodeMarshaler(t *testing.T) {
+	buf, contentType, err := Encode(encoderRequest{Shout: "hello"})
+	if err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+
+	parts := readParts(t, buf, params["boundary"])
+	if parts["shout"] != "HELLO" {
+		t.Errorf("shout = %q, want %q", parts["shout"], "HELLO")
+	}
+}
+
+func TestEncodePointerToReaderIsStreamedNotDereferenced(t *testing.T) {
+	req := struct {
+		Doc *bytes.Reader `form:"doc" filename:"doc.txt"`
+	}{Doc: bytes.NewReader([]byte("doc contents"))}
+
+	buf, contentType, err := Encode(req)
+	if err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+
+	parts := readParts(t, buf, params["boundary"])
+	if parts["doc"] != "doc contents" {
+		t.Errorf("doc = %q, want %q", parts["doc"], "doc contents")
+	}
+}
+
+func TestEncoderRegisterType(t *testing.T) {
+	e := NewEncoder()
+	e.RegisterType(reflect.TypeOf(0), func(w *multipart.Writer, fieldName string, field reflect.Value, _ reflect.StructField) error {
+		fw, err := w.CreateFormField(fieldName)
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(fw, "custom:%d", field.Int())
+		return err
+	})
+
+	buf, contentType, err := e.Encode(encoderRequest{Custom: 7})
+	if err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+
+	parts := readParts(t, buf, params["boundary"])
+	if parts["custom"] != "custom:7" {
+		t.Errorf("custom = %q, want %q", parts["custom"], "custom:7")
+	}
+}
@@ -0,0 +1,13 @@
+package multipart
+
+import "net/textproto"
+
+// Marshaler is implemented by types that know how to encode themselves into
+// a single multipart part. Encode checks for it on each field, by value and
+// by pointer, before falling back to any registered type/kind encoder or the
+// built-in kind-based defaults - so types that serialize poorly as JSON
+// (time.Time, uuid.UUID, decimal.Decimal, *multipart.FileHeader, ...) can
+// control their own wire format without the caller forking this package.
+type Marshaler interface {
+	MarshalMultipart() (data []byte, header textproto.MIMEHeader, err error)
+}
@@ -3,7 +3,6 @@ package multipart
 import (
 	"bytes"
 	"encoding/json"
-	"fmt"
 	"io"
 	"mime"
 	"mime/multipart"
@@ -21,134 +20,151 @@ const DefaultFileExtension = ""
 // and any error that occurred during encoding.
 //
 // Struct fields are encoded based on their types:
-// - String fields are encoded as form fields
-// - Numeric fields (int, uint, float) are converted to strings and encoded as form fields
-// - Boolean fields are converted to strings and encoded as form fields
-// - []byte fields are encoded as files with automatic extension detection
-// - Other slices are encoded as multiple form fields with the same name
-// - Struct fields are JSON-encoded and sent as form fields
+//   - String fields are encoded as form fields
+//   - Numeric fields (int, uint, float) are converted to strings and encoded as form fields
+//   - Boolean fields are converted to strings and encoded as form fields
+//   - []byte fields are encoded as files with automatic extension detection
+//   - Other slices are encoded as multiple form fields with the same name
+//   - Struct, slice-of-struct, and map fields are JSON-encoded into a single
+//     form field by default; pointer fields are dereferenced (a nil pointer
+//     is skipped). Use EncodeWithOptions with NestedStyle: BracketNotation
+//     to instead recursively encode them as bracket-notation names
+//     (user[address], items[0][name], meta[key]), which ParseMultipartForm
+//     and most server-side multipart form decoders understand natively
+//     (this package's Decode does not map such names back onto nested
+//     fields).
 //
 // Tags can be used to customize encoding:
-// - `form:"fieldname"` sets the form field name (defaults to lowercase field name)
-// - `form:"-"` skips the field
-// - `filename:"custom.ext"` sets custom filename for []byte fields
+//   - `form:"fieldname"` sets the form field name (defaults to lowercase field name)
+//   - `form:"-"` skips the field
+//   - `filename:"custom.ext"` sets custom filename for []byte fields
+//   - `contentType:"..."` overrides a []byte or file field's Content-Type
+//     (default application/octet-stream)
+//   - `contentEncoding:"gzip"` gzip-compresses a []byte or file field's body;
+//     a []byte payload smaller than gzipMinSize is left uncompressed, while
+//     io.Reader, *os.File and *multipart.FileHeader bodies are compressed
+//     whatever their size
+//
+// Encode is a thin wrapper over DefaultEncoder.Encode; use RegisterType or
+// RegisterKind on DefaultEncoder, or a dedicated Encoder built with
+// NewEncoder, to customize how specific types are encoded, or have a
+// field's type implement Marshaler directly.
+//
+// For very large payloads, prefer EncodeStream, which streams the parts
+// instead of buffering them in memory.
 func Encode(req any) (*bytes.Buffer, string, error) {
-	var b bytes.Buffer
-	w := multipart.NewWriter(&b)
+	return DefaultEncoder.Encode(req)
+}
 
-	v := reflect.ValueOf(req)
-	if v.Kind() == reflect.Ptr {
-		v = v.Elem()
+// formFieldName resolves the form field name for a struct field from its
+// `form:"..."` tag (defaulting to the lowercase field name), and reports
+// whether the field should be skipped entirely (`form:"-"`).
+func formFieldName(fieldType reflect.StructField) (name string, skip bool) {
+	name = fieldType.Tag.Get("form")
+	if name == "" {
+		name = strings.ToLower(fieldType.Name)
 	}
+	return name, name == "-"
+}
 
-	if v.Kind() != reflect.Struct {
-		return nil, "", fmt.Errorf("req must be a struct")
+// writeField writes a single struct field to w as either a form field or a
+// form file, dispatching on the field's concrete type and, failing that,
+// its reflect.Kind.
+func writeField(w *multipart.Writer, fieldName string, field reflect.Value, fieldType reflect.StructField) error {
+	var (
+		fw  io.Writer
+		err error
+	)
+
+	if ok, err := writeFileField(w, fieldName, field, fieldType); ok {
+		return err
 	}
 
-	t := v.Type()
-	for i := 0; i < v.NumField(); i++ {
-		field := v.Field(i)
-		fieldType := t.Field(i)
+	switch field.Kind() {
+	case reflect.String:
+		if field.String() != "" {
+			fw, err = w.CreateFormField(fieldName)
+			if err == nil {
+				_, err = fw.Write([]byte(field.String()))
+			}
+		}
 
-		fieldName := fieldType.Tag.Get("form")
-		if fieldName == "" {
-			fieldName = strings.ToLower(fieldType.Name)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		fw, err = w.CreateFormField(fieldName)
+		if err == nil {
+			_, err = fw.Write([]byte(strconv.FormatInt(field.Int(), 10)))
 		}
-		if fieldName == "-" {
-			continue
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		fw, err = w.CreateFormField(fieldName)
+		if err == nil {
+			_, err = fw.Write([]byte(strconv.FormatUint(field.Uint(), 10)))
 		}
 
-		var (
-			fw  io.Writer
-			err error
-		)
-		switch field.Kind() {
-		case reflect.String:
-			if field.String() != "" {
-				fw, err = w.CreateFormField(fieldName)
-				if err == nil {
-					_, err = fw.Write([]byte(field.String()))
-				}
-			}
+	case reflect.Float32, reflect.Float64:
+		fw, err = w.CreateFormField(fieldName)
+		if err == nil {
+			_, err = fw.Write([]byte(strconv.FormatFloat(field.Float(), 'f', -1, 64)))
+		}
 
-		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-			fw, err = w.CreateFormField(fieldName)
-			if err == nil {
-				_, err = fw.Write([]byte(strconv.FormatInt(field.Int(), 10)))
-			}
+	case reflect.Bool:
+		fw, err = w.CreateFormField(fieldName)
+		if err == nil {
+			_, err = fw.Write([]byte(strconv.FormatBool(field.Bool())))
+		}
 
-		case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-			fw, err = w.CreateFormField(fieldName)
-			if err == nil {
-				_, err = fw.Write([]byte(strconv.FormatUint(field.Uint(), 10)))
+	case reflect.Slice:
+		if field.Type().Elem().Kind() == reflect.Uint8 && !field.IsNil() {
+			filename := fieldType.Tag.Get("filename")
+			if filename == "" {
+				ext := getExtensionFromContent(field.Bytes())
+				if ext == "" {
+					ext = DefaultFileExtension
+				}
+				filename = fieldName + ext
 			}
 
-		case reflect.Float32, reflect.Float64:
-			fw, err = w.CreateFormField(fieldName)
+			var pw io.WriteCloser
+			pw, err = createPart(w, fieldName, filename, fieldType, field.Len())
 			if err == nil {
-				_, err = fw.Write([]byte(strconv.FormatFloat(field.Float(), 'f', -1, 64)))
+				_, err = pw.Write(field.Bytes())
 			}
-
-		case reflect.Bool:
-			fw, err = w.CreateFormField(fieldName)
 			if err == nil {
-				_, err = fw.Write([]byte(strconv.FormatBool(field.Bool())))
+				err = pw.Close()
 			}
-
-		case reflect.Slice:
-			if field.Type().Elem().Kind() == reflect.Uint8 && !field.IsNil() {
-				filename := fieldType.Tag.Get("filename")
-				if filename == "" {
-					ext := getExtensionFromContent(field.Bytes())
-					if ext == "" {
-						ext = DefaultFileExtension
-					}
-					filename = fieldName + ext
-				}
-
-				fw, err = w.CreateFormFile(fieldName, filename)
+		} else if !field.IsNil() {
+			// Handle slice of primitive values (as multiple form fields with the same name)
+			for j := 0; j < field.Len(); j++ {
+				elem := field.Index(j)
+				fw, err = w.CreateFormField(fieldName)
 				if err == nil {
-					_, err = fw.Write(field.Bytes())
-				}
-			} else if !field.IsNil() {
-				// Handle slice of primitive values (as multiple form fields with the same name)
-				for j := 0; j < field.Len(); j++ {
-					elem := field.Index(j)
-					fw, err = w.CreateFormField(fieldName)
-					if err == nil {
-						switch elem.Kind() {
-						case reflect.String:
-							_, err = fw.Write([]byte(elem.String()))
-						case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
-							_, err = fw.Write([]byte(strconv.FormatInt(elem.Int(), 10)))
-						case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
-							_, err = fw.Write([]byte(strconv.FormatUint(elem.Uint(), 10)))
-						}
+					switch elem.Kind() {
+					case reflect.String:
+						_, err = fw.Write([]byte(elem.String()))
+					case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+						_, err = fw.Write([]byte(strconv.FormatInt(elem.Int(), 10)))
+					case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+						_, err = fw.Write([]byte(strconv.FormatUint(elem.Uint(), 10)))
 					}
 				}
-			}
-
-		case reflect.Struct:
-			var jsonData []byte
-			jsonData, err = json.Marshal(field.Interface())
-			if err == nil {
-				fw, err = w.CreateFormField(fieldName)
-				if err == nil {
-					_, err = fw.Write(jsonData)
+				if err != nil {
+					return err
 				}
 			}
 		}
 
-		if err != nil {
-			return nil, "", err
+	case reflect.Struct:
+		var jsonData []byte
+		jsonData, err = json.Marshal(field.Interface())
+		if err == nil {
+			fw, err = w.CreateFormField(fieldName)
+			if err == nil {
+				_, err = fw.Write(jsonData)
+			}
 		}
 	}
 
-	if err := w.Close(); err != nil {
-		return nil, "", fmt.Errorf("failed to close multipart writer %w", err)
-	}
-
-	return &b, w.FormDataContentType(), nil
+	return err
 }
 
 func getExtensionFromContent(data []byte) string {
@@ -40,17 +40,17 @@ func TestEncodeMultipart(t *testing.T) {
 		},
 	}
 
-	buf, contentType, err := EncodeMultipart(req)
+	buf, contentType, err := Encode(req)
 	if err != nil {
-		t.Fatalf("EncodeMultipart returned an error: %v", err)
+		t.Fatalf("Encode returned an error: %v", err)
 	}
 
 	if buf == nil {
-		t.Fatal("EncodeMultipart returned a nil buffer")
+		t.Fatal("Encode returned a nil buffer")
 	}
 
 	if contentType == "" {
-		t.Fatal("EncodeMultipart returned an empty content type")
+		t.Fatal("Encode returned an empty content type")
 	}
 
 	// The multipart boundary is random, so we can't check the exact output
@@ -128,12 +128,12 @@ func TestIODetectExtension(t *testing.T) {
 		{
 			name:     "Unknown file type",
 			data:     []byte{0x00, 0x01, 0x02, 0x03},
-			expected: ".bin",
+			expected: "",
 		},
 		{
 			name:     "Too short",
 			data:     []byte{0x00, 0x01},
-			expected: ".bin",
+			expected: "",
 		},
 	}
 
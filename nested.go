@@ -0,0 +1,148 @@
+package multipart
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"reflect"
+	"sort"
+	"strconv"
+)
+
+// NestedStyle controls how Encode renders nested structs, slices of
+// structs, and maps.
+type NestedStyle int
+
+const (
+	// JSONBlob is the package's original behavior and the default: a
+	// nested struct, slice of structs, or map is JSON-encoded into a
+	// single form field. This is the zero value so existing callers keep
+	// working unchanged.
+	JSONBlob NestedStyle = iota
+
+	// BracketNotation emits PHP/Rails-style names - user[address],
+	// items[0][name], meta[key] - which ParseMultipartForm and most
+	// server-side multipart form decoders understand natively. Opt into
+	// this with EncodeWithOptions for interoperability with those
+	// consumers. This package's Decode does not map these names back onto
+	// nested fields; it reads a nested struct only from a JSONBlob part.
+	BracketNotation
+
+	// DottedPath emits dotted names - user.address, items.0.name,
+	// meta.key - as used by some form parsers in place of bracket
+	// notation.
+	DottedPath
+)
+
+// EncodeOptions customizes Encode's behavior. The zero value uses JSONBlob
+// and declaration-order field iteration, matching Encode's original,
+// unconfigured behavior.
+type EncodeOptions struct {
+	NestedStyle NestedStyle
+
+	// Deterministic, when true, sorts struct fields by their resolved form
+	// name (tag or lowercase field name) before encoding them, instead of
+	// using declaration order. Combined with Encoder.SetBoundary, this makes
+	// Encode's output byte-for-byte reproducible across runs.
+	Deterministic bool
+}
+
+// EncodeWithOptions is Encode with explicit control over nested-value
+// rendering via opts. Pass NestedStyle: BracketNotation (or DottedPath) to
+// opt a nested struct, slice of structs, or map field out of the default
+// JSON-blob encoding.
+func EncodeWithOptions(req any, opts EncodeOptions) (*bytes.Buffer, string, error) {
+	return DefaultEncoder.WithOptions(opts).Encode(req)
+}
+
+// writeJSONField JSON-encodes field's value into a single form field named
+// name, used for the JSONBlob NestedStyle across nested structs, maps, and
+// slices of structs.
+func writeJSONField(w *multipart.Writer, name string, field reflect.Value) error {
+	data, err := json.Marshal(field.Interface())
+	if err != nil {
+		return err
+	}
+	fw, err := w.CreateFormField(name)
+	if err != nil {
+		return err
+	}
+	_, err = fw.Write(data)
+	return err
+}
+
+// nestedName joins a parent field name with a child key according to style.
+func nestedName(prefix, key string, style NestedStyle) string {
+	if style == DottedPath {
+		return prefix + "." + key
+	}
+	return prefix + "[" + key + "]"
+}
+
+// encodeStructFields recursively encodes each field of v (a nested struct
+// value, not the top-level request) under prefix, honoring the same
+// `form:"..."` tag rules as the top-level struct.
+func (e *Encoder) encodeStructFields(w *multipart.Writer, prefix string, v reflect.Value) error {
+	t := v.Type()
+	for _, i := range orderedFieldIndices(t, e.Options.Deterministic) {
+		field := v.Field(i)
+		fieldType := t.Field(i)
+
+		name, skip := formFieldName(fieldType)
+		if skip {
+			continue
+		}
+
+		if err := e.encodeField(w, nestedName(prefix, name, e.Options.NestedStyle), field, fieldType); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeSliceOfStruct recursively encodes each element of a []T slice (T a
+// struct) under prefix, indexed as prefix[0], prefix[1], ...
+func (e *Encoder) encodeSliceOfStruct(w *multipart.Writer, prefix string, field reflect.Value) error {
+	if field.IsNil() {
+		return nil
+	}
+	for i := 0; i < field.Len(); i++ {
+		name := nestedName(prefix, strconv.Itoa(i), e.Options.NestedStyle)
+		if err := e.encodeStructFields(w, name, field.Index(i)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeMap encodes a map field under prefix. With JSONBlob it is written as
+// a single JSON-encoded field; otherwise each entry becomes its own part
+// named prefix[key] (or prefix.key for DottedPath), with keys sorted for
+// deterministic output.
+func (e *Encoder) encodeMap(w *multipart.Writer, prefix string, field reflect.Value) error {
+	if field.IsNil() {
+		return nil
+	}
+
+	if e.Options.NestedStyle == JSONBlob {
+		return writeJSONField(w, prefix, field)
+	}
+
+	keys := field.MapKeys()
+	sort.Slice(keys, func(i, j int) bool {
+		return fmt.Sprint(keys[i].Interface()) < fmt.Sprint(keys[j].Interface())
+	})
+
+	for _, k := range keys {
+		name := nestedName(prefix, fmt.Sprint(k.Interface()), e.Options.NestedStyle)
+		fw, err := w.CreateFormField(name)
+		if err != nil {
+			return err
+		}
+		if _, err := fw.Write([]byte(fmt.Sprint(field.MapIndex(k).Interface()))); err != nil {
+			return err
+		}
+	}
+	return nil
+}
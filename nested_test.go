@@ -0,0 +1,122 @@
+package multipart
+
+import (
+	"encoding/json"
+	"mime"
+	"testing"
+)
+
+type address struct {
+	City string `form:"city"`
+}
+
+type item struct {
+	Name string `form:"name"`
+}
+
+type nestedRequest struct {
+	User  address           `form:"user"`
+	Items []item            `form:"items"`
+	Meta  map[string]string `form:"meta"`
+	Note  *string           `form:"note"`
+}
+
+func TestEncodeNestedBracketNotation(t *testing.T) {
+	note := "hello"
+	req := nestedRequest{
+		User:  address{City: "Berlin"},
+		Items: []item{{Name: "a"}, {Name: "b"}},
+		Meta:  map[string]string{"k1": "v1", "k2": "v2"},
+		Note:  &note,
+	}
+
+	buf, contentType, err := EncodeWithOptions(req, EncodeOptions{NestedStyle: BracketNotation})
+	if err != nil {
+		t.Fatalf("EncodeWithOptions returned an error: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+
+	parts := readParts(t, buf, params["boundary"])
+
+	want := map[string]string{
+		"user[city]":     "Berlin",
+		"items[0][name]": "a",
+		"items[1][name]": "b",
+		"meta[k1]":       "v1",
+		"meta[k2]":       "v2",
+		"note":           "hello",
+	}
+	for name, value := range want {
+		if got, ok := parts[name]; !ok || got != value {
+			t.Errorf("parts[%q] = %q, %v; want %q", name, got, ok, value)
+		}
+	}
+}
+
+func TestEncodeNestedNilPointerSkipped(t *testing.T) {
+	req := nestedRequest{User: address{City: "Berlin"}}
+
+	buf, contentType, err := Encode(req)
+	if err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+
+	parts := readParts(t, buf, params["boundary"])
+	if _, ok := parts["note"]; ok {
+		t.Error("expected nil pointer field 'note' to be skipped")
+	}
+}
+
+func TestEncodeNestedJSONBlobDefault(t *testing.T) {
+	req := nestedRequest{User: address{City: "Berlin"}}
+
+	buf, contentType, err := Encode(req)
+	if err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+
+	parts := readParts(t, buf, params["boundary"])
+	if _, ok := parts["user[city]"]; ok {
+		t.Error("default (JSONBlob) mode should not emit bracket-notation parts")
+	}
+	if parts["user"] == "" {
+		t.Error("default (JSONBlob) mode should emit a single JSON-encoded 'user' field")
+	}
+}
+
+func TestEncodeNestedSliceOfStructJSONBlobDefault(t *testing.T) {
+	req := nestedRequest{Items: []item{{Name: "a"}, {Name: "b"}}}
+
+	buf, contentType, err := Encode(req)
+	if err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+
+	parts := readParts(t, buf, params["boundary"])
+	if _, ok := parts["items[0][name]"]; ok {
+		t.Error("default (JSONBlob) mode should not emit bracket-notation parts for a slice of structs")
+	}
+
+	var items []item
+	if err := json.Unmarshal([]byte(parts["items"]), &items); err != nil {
+		t.Fatalf("failed to unmarshal 'items' field as JSON: %v", err)
+	}
+	if len(items) != 2 || items[0].Name != "a" || items[1].Name != "b" {
+		t.Errorf("items = %+v, want [{a} {b}]", items)
+	}
+}
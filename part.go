@@ -0,0 +1,72 @@
+package multipart
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/textproto"
+	"reflect"
+)
+
+// gzipMinSize is the smallest []byte payload EncodeStream/Encode will bother
+// gzip-compressing when `contentEncoding:"gzip"` is set; below this, the
+// gzip container overhead outweighs any savings. Streamed bodies
+// (io.Reader, *os.File, *multipart.FileHeader) are not checked against it.
+const gzipMinSize = 1024 // bytes
+
+// unknownSize is passed as dataLen by callers that stream a part without
+// knowing its length up front, so the gzip size threshold is skipped.
+const unknownSize = -1
+
+// createPart opens a part on w for fieldName, honoring the field's
+// `contentType:"..."` and `contentEncoding:"gzip"` tags. filename is empty
+// for plain form fields and set for file parts. dataLen is the payload size
+// in bytes if known, or unknownSize for streamed content; it only affects
+// whether gzip is applied below gzipMinSize.
+//
+// The returned writer must be closed once the part's content has been
+// written, which flushes (and, for gzip, finalizes) the part before the
+// next one is created.
+func createPart(w *multipart.Writer, fieldName, filename string, fieldType reflect.StructField, dataLen int) (io.WriteCloser, error) {
+	contentType := fieldType.Tag.Get("contentType")
+	gzipRequested := fieldType.Tag.Get("contentEncoding") == "gzip"
+	useGzip := gzipRequested && (dataLen == unknownSize || dataLen >= gzipMinSize)
+
+	header := make(textproto.MIMEHeader)
+	if filename != "" {
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q; filename=%q`, fieldName, filename))
+		if contentType == "" {
+			contentType = "application/octet-stream"
+		}
+	} else {
+		header.Set("Content-Disposition", fmt.Sprintf(`form-data; name=%q`, fieldName))
+	}
+
+	if contentType != "" {
+		header.Set("Content-Type", contentType)
+	}
+	if useGzip {
+		header.Set("Content-Encoding", "gzip")
+	}
+
+	pw, err := w.CreatePart(header)
+	if err != nil {
+		return nil, err
+	}
+
+	if useGzip {
+		return gzip.NewWriter(pw), nil
+	}
+
+	return nopWriteCloser{pw}, nil
+}
+
+// nopWriteCloser adapts an io.Writer without its own Close method (such as
+// the writer returned by multipart.Writer.CreatePart) to an io.WriteCloser
+// whose Close is a no-op, so callers can treat every part uniformly.
+type nopWriteCloser struct {
+	io.Writer
+}
+
+func (nopWriteCloser) Close() error { return nil }
@@ -0,0 +1,79 @@
+package multipart
+
+import (
+	"compress/gzip"
+	"io"
+	"mime"
+	"mime/multipart"
+	"strings"
+	"testing"
+)
+
+type contentTypeRequest struct {
+	Payload []byte `form:"payload" contentType:"application/json" contentEncoding:"gzip"`
+	Small   []byte `form:"small" contentEncoding:"gzip"`
+}
+
+func TestEncodeContentTypeAndGzip(t *testing.T) {
+	large := strings.Repeat("a", gzipMinSize+1)
+
+	req := contentTypeRequest{
+		Payload: []byte(large),
+		Small:   []byte("tiny"),
+	}
+
+	buf, contentType, err := Encode(req)
+	if err != nil {
+		t.Fatalf("Encode returned an error: %v", err)
+	}
+
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+
+	r := multipart.NewReader(buf, params["boundary"])
+	for {
+		part, err := r.NextPart()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read part: %v", err)
+		}
+
+		switch part.FormName() {
+		case "payload":
+			if got := part.Header.Get("Content-Type"); got != "application/json" {
+				t.Errorf("payload Content-Type = %q, want application/json", got)
+			}
+			if got := part.Header.Get("Content-Encoding"); got != "gzip" {
+				t.Errorf("payload Content-Encoding = %q, want gzip", got)
+			}
+
+			gr, err := gzip.NewReader(part)
+			if err != nil {
+				t.Fatalf("failed to create gzip reader: %v", err)
+			}
+			data, err := io.ReadAll(gr)
+			if err != nil {
+				t.Fatalf("failed to read gzip payload: %v", err)
+			}
+			if string(data) != large {
+				t.Error("gzip payload round-trip mismatch")
+			}
+
+		case "small":
+			if got := part.Header.Get("Content-Encoding"); got != "" {
+				t.Errorf("small Content-Encoding = %q, want empty (below gzipMinSize)", got)
+			}
+			data, err := io.ReadAll(part)
+			if err != nil {
+				t.Fatalf("failed to read small part: %v", err)
+			}
+			if string(data) != "tiny" {
+				t.Errorf("small part = %q, want tiny", data)
+			}
+		}
+	}
+}
@@ -0,0 +1,148 @@
+package multipart
+
+import (
+	"fmt"
+	"io"
+	"mime/multipart"
+	"os"
+	"path/filepath"
+	"reflect"
+)
+
+var (
+	readerType     = reflect.TypeOf((*io.Reader)(nil)).Elem()
+	fileHeaderType = reflect.TypeOf((*multipart.FileHeader)(nil))
+	osFileType     = reflect.TypeOf((*os.File)(nil))
+)
+
+// isNilable reports whether k is a kind reflect.Value.IsNil accepts; a field
+// whose static type implements an interface (such as io.Reader) may still be
+// a non-nilable concrete kind like Struct, for which IsNil panics.
+func isNilable(k reflect.Kind) bool {
+	switch k {
+	case reflect.Chan, reflect.Func, reflect.Interface, reflect.Map, reflect.Ptr, reflect.Slice, reflect.UnsafePointer:
+		return true
+	}
+	return false
+}
+
+// EncodeStream is the streaming counterpart to Encode. Instead of
+// buffering the whole multipart body in memory, it writes parts to an
+// io.Pipe from a background goroutine and returns the read end along
+// with the content type, so the result can be passed directly to
+// http.NewRequest without materializing the body.
+//
+// It supports the same field types as Encode. Fields typed as io.Reader,
+// *os.File, or *multipart.FileHeader are copied into their part via
+// io.Copy, which here writes to the pipe, so their contents are not read
+// into memory first.
+//
+// Any error produced while writing is delivered to the reader via
+// io.PipeWriter.CloseWithError, so it surfaces from the first read (or
+// copy) that consumes pr past the failure point.
+func EncodeStream(req any) (io.ReadCloser, string, error) {
+	pr, pw := io.Pipe()
+	w := multipart.NewWriter(pw)
+
+	if DefaultEncoder.boundary != "" {
+		if err := w.SetBoundary(DefaultEncoder.boundary); err != nil {
+			pr.Close()
+			return nil, "", err
+		}
+	}
+
+	v := reflect.ValueOf(req)
+	if v.Kind() == reflect.Ptr {
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		pr.Close()
+		return nil, "", fmt.Errorf("req must be a struct")
+	}
+
+	contentType := w.FormDataContentType()
+
+	go func() {
+		err := DefaultEncoder.writeFields(w, req)
+		if err == nil {
+			err = w.Close()
+		}
+		pw.CloseWithError(err)
+	}()
+
+	return pr, contentType, nil
+}
+
+// writeFileField writes field as a form file if it is a recognized
+// streamable file type (io.Reader, *os.File, or *multipart.FileHeader;
+// []byte fields are handled by writeField). It reports whether it handled the field at
+// all, so callers can fall through to the generic kind-based encoding
+// otherwise.
+func writeFileField(w *multipart.Writer, fieldName string, field reflect.Value, fieldType reflect.StructField) (bool, error) {
+	switch {
+	case field.Type() == fileHeaderType:
+		fh, _ := field.Interface().(*multipart.FileHeader)
+		if fh == nil {
+			return true, nil
+		}
+		f, err := fh.Open()
+		if err != nil {
+			return true, err
+		}
+		defer f.Close()
+
+		pw, err := createPart(w, fieldName, fh.Filename, fieldType, unknownSize)
+		if err != nil {
+			return true, err
+		}
+		_, err = io.Copy(pw, f)
+		if err == nil {
+			err = pw.Close()
+		}
+		return true, err
+
+	case field.Type() == osFileType:
+		f, _ := field.Interface().(*os.File)
+		if f == nil {
+			return true, nil
+		}
+
+		filename := fieldType.Tag.Get("filename")
+		if filename == "" {
+			filename = filepath.Base(f.Name())
+		}
+
+		pw, err := createPart(w, fieldName, filename, fieldType, unknownSize)
+		if err != nil {
+			return true, err
+		}
+		_, err = io.Copy(pw, f)
+		if err == nil {
+			err = pw.Close()
+		}
+		return true, err
+
+	case field.Type().Implements(readerType):
+		if isNilable(field.Kind()) && field.IsNil() {
+			return true, nil
+		}
+		r := field.Interface().(io.Reader)
+
+		filename := fieldType.Tag.Get("filename")
+		if filename == "" {
+			filename = fieldName
+		}
+
+		pw, err := createPart(w, fieldName, filename, fieldType, unknownSize)
+		if err != nil {
+			return true, err
+		}
+		_, err = io.Copy(pw, r)
+		if err == nil {
+			err = pw.Close()
+		}
+		return true, err
+	}
+
+	return false, nil
+}
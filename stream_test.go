@@ -0,0 +1,108 @@
+package multipart
+
+import (
+	"bytes"
+	"io"
+	"mime"
+	"mime/multipart"
+	"os"
+	"testing"
+)
+
+type streamRequest struct {
+	Name string    `form:"name"`
+	Doc  io.Reader `form:"doc" filename:"doc.txt"`
+	File *os.File  `form:"file"`
+}
+
+func TestEncodeStream(t *testing.T) {
+	f, err := os.CreateTemp(t.TempDir(), "upload-*.txt")
+	if err != nil {
+		t.Fatalf("failed to create temp file: %v", err)
+	}
+	if _, err := f.WriteString("file contents"); err != nil {
+		t.Fatalf("failed to write temp file: %v", err)
+	}
+	if _, err := f.Seek(0, io.SeekStart); err != nil {
+		t.Fatalf("failed to rewind temp file: %v", err)
+	}
+	defer f.Close()
+
+	req := streamRequest{
+		Name: "John Doe",
+		Doc:  bytes.NewReader([]byte("doc contents")),
+		File: f,
+	}
+
+	rc, contentType, err := EncodeStream(req)
+	if err != nil {
+		t.Fatalf("EncodeStream returned an error: %v", err)
+	}
+	defer rc.Close()
+
+	_, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("failed to parse content type: %v", err)
+	}
+
+	r := multipart.NewReader(rc, params["boundary"])
+	form, err := r.ReadForm(0)
+	if err != nil {
+		t.Fatalf("failed to read multipart form: %v", err)
+	}
+	defer form.RemoveAll()
+
+	if got := form.Value["name"]; len(got) != 1 || got[0] != "John Doe" {
+		t.Errorf("Value[name] = %v, want [John Doe]", got)
+	}
+
+	fileContents := map[string]string{}
+	for fieldName, files := range form.File {
+		for _, fh := range files {
+			part, err := fh.Open()
+			if err != nil {
+				t.Fatalf("failed to open part %q: %v", fieldName, err)
+			}
+			data, err := io.ReadAll(part)
+			part.Close()
+			if err != nil {
+				t.Fatalf("failed to read part %q: %v", fieldName, err)
+			}
+			fileContents[fieldName] = string(data)
+		}
+	}
+
+	if fileContents["doc"] != "doc contents" {
+		t.Errorf("doc part = %q, want %q", fileContents["doc"], "doc contents")
+	}
+	if fileContents["file"] != "file contents" {
+		t.Errorf("file part = %q, want %q", fileContents["file"], "file contents")
+	}
+}
+
+// valueReader is an io.Reader implemented on a non-nilable (struct) kind, to
+// exercise the case where writeFileField's reader branch must not call
+// reflect.Value.IsNil.
+type valueReader struct {
+	data string
+}
+
+func (r valueReader) Read(p []byte) (int, error) {
+	return copy(p, r.data), io.EOF
+}
+
+func TestEncodeStreamValueKindReader(t *testing.T) {
+	req := struct {
+		Doc valueReader `form:"doc" filename:"doc.txt"`
+	}{Doc: valueReader{data: "doc contents"}}
+
+	rc, _, err := EncodeStream(req)
+	if err != nil {
+		t.Fatalf("EncodeStream returned an error: %v", err)
+	}
+	defer rc.Close()
+
+	if _, err := io.ReadAll(rc); err != nil {
+		t.Fatalf("failed to read encoded stream: %v", err)
+	}
+}